@@ -0,0 +1,101 @@
+// Package main implements a standalone snapshot CLI, invoked directly rather than as a subcommand:
+//
+//	snapshot export --chain 1 --out file.snap
+//	snapshot import --chain 1 --in file.snap
+//
+// It lets an operator seed a fresh instance from a trusted dump instead of re-scraping historical
+// prices and block times from RPC.
+//
+// Deviation from the request: this was asked for as `ydaemon snapshot export --chain 1 --out
+// file.snap`, i.e. a subcommand of the main ydaemon binary's dispatcher. This tree has no
+// cmd/ydaemon entry point for it to be wired into — only this package and cmd/snapshot exist under
+// cmd/ — so it ships here as its own `go run ./cmd/snapshot` binary instead. Whoever owns the real
+// dispatcher should confirm whether to import runExport/runImport from here as a subcommand, or
+// have this package's main() stay the single entry point.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/yearn/ydaemon/common/logs"
+	"github.com/yearn/ydaemon/common/store"
+	_ "github.com/yearn/ydaemon/common/store/backend/badger"
+	_ "github.com/yearn/ydaemon/common/store/backend/mysql"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(1)
+	}
+
+	switch os.Args[1] {
+	case `export`:
+		runExport(os.Args[2:])
+	case `import`:
+		runImport(os.Args[2:])
+	default:
+		usage()
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage: snapshot export --chain <id> --out <file>`)
+	fmt.Fprintln(os.Stderr, `       snapshot import --chain <id> --in <file>`)
+}
+
+func runExport(args []string) {
+	flagSet := flag.NewFlagSet(`export`, flag.ExitOnError)
+	chainID := flagSet.Uint64(`chain`, 0, `chain ID to export`)
+	out := flagSet.String(`out`, ``, `path of the snapshot file to write`)
+	if err := flagSet.Parse(args); err != nil {
+		fatal(err)
+	}
+	if *chainID == 0 || *out == `` {
+		usage()
+		os.Exit(1)
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		fatal(err)
+	}
+	defer file.Close()
+
+	if err := store.ExportSnapshot(*chainID, file); err != nil {
+		fatal(err)
+	}
+	logs.Info(`Snapshot for chain ` + flagSet.Lookup(`chain`).Value.String() + ` written to ` + *out)
+}
+
+func runImport(args []string) {
+	flagSet := flag.NewFlagSet(`import`, flag.ExitOnError)
+	chainID := flagSet.Uint64(`chain`, 0, `chain ID to import into`)
+	in := flagSet.String(`in`, ``, `path of the snapshot file to read`)
+	if err := flagSet.Parse(args); err != nil {
+		fatal(err)
+	}
+	if *chainID == 0 || *in == `` {
+		usage()
+		os.Exit(1)
+	}
+
+	file, err := os.Open(*in)
+	if err != nil {
+		fatal(err)
+	}
+	defer file.Close()
+
+	if err := store.ImportSnapshot(*chainID, file); err != nil {
+		fatal(err)
+	}
+	logs.Info(`Snapshot ` + *in + ` imported for chain ` + flagSet.Lookup(`chain`).Value.String())
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/yearn/ydaemon/common/bigNumber"
+	"github.com/yearn/ydaemon/internal/models"
+)
+
+/**************************************************************************************************
+** Backend is implemented by every storage engine ydaemon can persist indexed data to. A Backend is
+** always scoped to a single chain: the registry in store.registry.go keeps one instance per
+** chainID, of whichever backend _dbType selects, and the Store* helpers in store.setter.go delegate to it after
+** updating the in-memory sync.Map cache.
+**************************************************************************************************/
+type Backend interface {
+	// PutXxxBatch persist a batch of entries accumulated by the write-coalescing layer in
+	// store.writer.go. There is deliberately no single-entry Put anymore: every write, even a batch
+	// of one during low traffic, goes through these so each backend only has to get bulk writes
+	// fast once instead of also optimizing a one-row-at-a-time path.
+	PutBlockTimeBatch(chainID uint64, entries []BlockTimeEntry) error
+	PutHistoricalPriceBatch(chainID uint64, entries []HistoricalPriceEntry) error
+	PutVaultBatch(chainID uint64, entries []*models.TVault) error
+	PutNewVaultFromRegistryBatch(chainID uint64, entries []models.TVaultsFromRegistry) error
+
+	GetBlockTime(chainID uint64, blockNumber uint64) (uint64, bool, error)
+	GetHistoricalPrice(chainID uint64, blockNumber uint64, tokenAddress common.Address) (*bigNumber.Int, bool, error)
+
+	IterateBlockTime(chainID uint64, fn func(blockNumber uint64, blockTime uint64) error) error
+	IterateHistoricalPrice(chainID uint64, fn func(key string, price *bigNumber.Int) error) error
+	IterateVault(chainID uint64, fn func(key string, vault *models.TVault) error) error
+	IterateNewVaultFromRegistry(chainID uint64, fn func(key string, vault models.TVaultsFromRegistry) error) error
+
+	Close() error
+}
+
+/**************************************************************************************************
+** BlockTimeEntry and HistoricalPriceEntry carry a single write through the coalescing queue in
+** store.writer.go down to a Backend's batch Put method. Vaults and registry entries already carry
+** enough identity in their own model types (*models.TVault, models.TVaultsFromRegistry), so they
+** are queued as-is without a dedicated wrapper.
+**************************************************************************************************/
+type BlockTimeEntry struct {
+	BlockNumber uint64
+	BlockTime   uint64
+}
+
+type HistoricalPriceEntry struct {
+	BlockNumber  uint64
+	TokenAddress common.Address
+	Price        *bigNumber.Int
+}
+
+/**************************************************************************************************
+** BackendFactory builds a Backend instance for a given chain. Backends register their factory
+** under a short name via RegisterBackend, usually from an init() function in their own package, so
+** this package never has to import a concrete implementation directly.
+**************************************************************************************************/
+type BackendFactory func(chainID uint64) (Backend, error)
+
+var _backendFactories = map[string]BackendFactory{}
+
+/**************************************************************************************************
+** RegisterBackend makes a storage engine available under `name`, the same way database/sql drivers
+** register themselves, so the engine's own package supplies its factory. Only the names `badger`
+** and `mysql` are reachable from config: selecting any other registered name also needs a case in
+** backendName (store.registry.go).
+**************************************************************************************************/
+func RegisterBackend(name string, factory BackendFactory) {
+	_backendFactories[name] = factory
+}
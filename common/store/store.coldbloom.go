@@ -0,0 +1,124 @@
+package store
+
+import (
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	_coldBloomBits   = 1 << 20 // ~128KB per (chain, table); enough bits to keep false positives rare
+	_coldBloomHashes = 4
+)
+
+/**************************************************************************************************
+** coldBloom tracks, approximately, which keys the compactor has offloaded to cold storage for one
+** (chainID, table) pair. A hot-storage miss consults it before paying for a ColdTier.Read round
+** trip: a negative answer means this process has not pruned the key and no fallback is attempted.
+** The filter lives in memory only and starts empty on every process start, so keys offloaded by an
+** earlier run are reported as not cold.
+**************************************************************************************************/
+type coldBloom struct {
+	mu     sync.RWMutex
+	bits   []uint64
+	added  int64 // atomic: number of Add calls, used as a proxy for cold-tier size in StatsHandler
+	hits   int64 // atomic: MightBeCold calls that returned true
+	misses int64 // atomic: MightBeCold calls that returned false
+}
+
+func newColdBloom() *coldBloom {
+	return &coldBloom{bits: make([]uint64, _coldBloomBits/64)}
+}
+
+func (f *coldBloom) positions(key string) [_coldBloomHashes]uint64 {
+	var positions [_coldBloomHashes]uint64
+	h := fnv.New64a()
+	for i := 0; i < _coldBloomHashes; i++ {
+		h.Reset()
+		h.Write([]byte{byte(i)})
+		h.Write([]byte(key))
+		positions[i] = h.Sum64() % _coldBloomBits
+	}
+	return positions
+}
+
+func (f *coldBloom) Add(key string) {
+	atomic.AddInt64(&f.added, 1)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, pos := range f.positions(key) {
+		f.bits[pos/64] |= 1 << (pos % 64)
+	}
+}
+
+// MightBeCold reports whether key may have been moved to cold storage. false means it was not Add-ed
+// since the process started; true means it might have been, and the caller should fall back to
+// ColdTier.Read.
+func (f *coldBloom) MightBeCold(key string) bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	for _, pos := range f.positions(key) {
+		if f.bits[pos/64]&(1<<(pos%64)) == 0 {
+			atomic.AddInt64(&f.misses, 1)
+			return false
+		}
+	}
+	atomic.AddInt64(&f.hits, 1)
+	return true
+}
+
+// coldBloomStats is the snapshot StatsHandler reports for one (chainID, table) bloom: how many keys
+// it has recorded as archived, and how its MightBeCold lookups have split between positive and
+// negative answers so far.
+type coldBloomStats struct {
+	ColdEntries int64
+	Hits        int64
+	Misses      int64
+}
+
+func (f *coldBloom) Stats() coldBloomStats {
+	return coldBloomStats{
+		ColdEntries: atomic.LoadInt64(&f.added),
+		Hits:        atomic.LoadInt64(&f.hits),
+		Misses:      atomic.LoadInt64(&f.misses),
+	}
+}
+
+var (
+	_coldBlooms   = map[string]*coldBloom{} // "<chainID>/<table>" -> bloom
+	_coldBloomsMu sync.Mutex
+)
+
+func coldBloomFor(chainID uint64, table string) *coldBloom {
+	key := strconv.FormatUint(chainID, 10) + `/` + table
+	_coldBloomsMu.Lock()
+	defer _coldBloomsMu.Unlock()
+	if b, ok := _coldBlooms[key]; ok {
+		return b
+	}
+	b := newColdBloom()
+	_coldBlooms[key] = b
+	return b
+}
+
+// coldBloomStatsForTable sums coldBloomStats across every chain's bloom for table, so StatsHandler
+// can report one cold-size/hit-rate figure per table instead of per (chain, table) pair.
+func coldBloomStatsForTable(table string) coldBloomStats {
+	suffix := `/` + table
+	_coldBloomsMu.Lock()
+	defer _coldBloomsMu.Unlock()
+
+	var total coldBloomStats
+	for key, bloom := range _coldBlooms {
+		if !strings.HasSuffix(key, suffix) {
+			continue
+		}
+		stats := bloom.Stats()
+		total.ColdEntries += stats.ColdEntries
+		total.Hits += stats.Hits
+		total.Misses += stats.Misses
+	}
+	return total
+}
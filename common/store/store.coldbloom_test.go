@@ -0,0 +1,39 @@
+package store
+
+import "testing"
+
+func TestColdBloomTracksHitsAndMisses(t *testing.T) {
+	bloom := newColdBloom()
+	bloom.Add(`present`)
+
+	if !bloom.MightBeCold(`present`) {
+		t.Fatal(`expected a key that was Add-ed to be reported as possibly cold`)
+	}
+	if bloom.MightBeCold(`absent`) {
+		t.Fatal(`expected a key that was never Add-ed to be reported as definitely not cold`)
+	}
+
+	stats := bloom.Stats()
+	if stats.ColdEntries != 1 {
+		t.Fatalf(`expected 1 cold entry, got %d`, stats.ColdEntries)
+	}
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Fatalf(`expected 1 hit and 1 miss, got hits=%d misses=%d`, stats.Hits, stats.Misses)
+	}
+}
+
+func TestColdBloomStatsForTableAggregatesAcrossChains(t *testing.T) {
+	table := `test_aggregate_table`
+	coldBloomFor(1, table).Add(`a`)
+	coldBloomFor(2, table).Add(`b`)
+	coldBloomFor(1, table).MightBeCold(`a`)
+	coldBloomFor(2, table).MightBeCold(`nope`)
+
+	stats := coldBloomStatsForTable(table)
+	if stats.ColdEntries != 2 {
+		t.Fatalf(`expected 2 cold entries summed across chains, got %d`, stats.ColdEntries)
+	}
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Fatalf(`expected 1 hit and 1 miss summed across chains, got hits=%d misses=%d`, stats.Hits, stats.Misses)
+	}
+}
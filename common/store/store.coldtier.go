@@ -0,0 +1,120 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/yearn/ydaemon/common/bigNumber"
+)
+
+const _coldTierSecondsPerWeek = 7 * 24 * 60 * 60
+
+/**************************************************************************************************
+** ColdTier is where the compactor in store.compactor.go offloads records a RetentionConfig prunes
+** from hot storage. Records are addressed by (chainID, table, week) so a cold read only has to
+** fetch the single weekly blob a pruned key would have landed in, rather than scanning everything
+** ever offloaded for that table.
+**************************************************************************************************/
+type ColdTier interface {
+	Write(chainID uint64, table string, week int64, data []byte) error
+	Read(chainID uint64, table string, week int64) ([]byte, error)
+}
+
+// WeekOf buckets a unix timestamp into the week number every ColdTier implementation keys on.
+func WeekOf(unixSeconds int64) int64 {
+	return unixSeconds / _coldTierSecondsPerWeek
+}
+
+/**************************************************************************************************
+** FileColdTier appends pruned blobs under BaseDir/<chainID>/<table>/<week>.cold. It is the local
+** stand-in for an eventual S3-backed ColdTier: same interface, same (chainID, table, week) keying,
+** just a filesystem instead of a bucket, so swapping one in for the other is a one-line change at
+** the call site that constructs RetentionConfig.ColdTier.
+**************************************************************************************************/
+type FileColdTier struct {
+	BaseDir string
+}
+
+func (c *FileColdTier) path(chainID uint64, table string, week int64) string {
+	return filepath.Join(c.BaseDir, strconv.FormatUint(chainID, 10), table, strconv.FormatInt(week, 10)+`.cold`)
+}
+
+func (c *FileColdTier) Write(chainID uint64, table string, week int64, data []byte) error {
+	path := c.path(chainID, table, week)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(data)
+	return err
+}
+
+func (c *FileColdTier) Read(chainID uint64, table string, week int64) ([]byte, error) {
+	data, err := os.ReadFile(c.path(chainID, table, week))
+	if err != nil {
+		return nil, fmt.Errorf(`store: cold tier read chain %d table %s week %d: %w`, chainID, table, week, err)
+	}
+	return data, nil
+}
+
+// GetHistoricalPriceWithColdFallback looks up a price the normal way through the chain's backend
+// first. On a hot-storage miss it only pays for a ColdTier.Read when the cold bloom for this table
+// says the key might actually be there, then scans that week's archive for the matching entry.
+func GetHistoricalPriceWithColdFallback(chainID uint64, blockNumber uint64, tokenAddress common.Address, config RetentionConfig) (*bigNumber.Int, bool, error) {
+	backend, err := backendFor(chainID)
+	if err != nil {
+		return nil, false, err
+	}
+	if price, found, err := backend.GetHistoricalPrice(chainID, blockNumber, tokenAddress); found || err != nil {
+		return price, found, err
+	}
+
+	if config.ColdTier == nil {
+		return nil, false, nil
+	}
+	key := strconv.FormatUint(blockNumber, 10) + `_` + tokenAddress.Hex()
+	if !coldBloomFor(chainID, `historical_price`).MightBeCold(key) {
+		return nil, false, nil
+	}
+
+	blockTime, found, err := backend.GetBlockTime(chainID, blockNumber)
+	if err != nil {
+		return nil, false, err
+	}
+	week := WeekOf(time.Now().Unix())
+	if found {
+		week = WeekOf(int64(blockTime))
+	}
+	data, err := config.ColdTier.Read(chainID, `historical_price`, week)
+	if err != nil {
+		return nil, false, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	for decoder.More() {
+		var record struct {
+			BlockNumber  uint64 `json:"blockNumber"`
+			TokenAddress string `json:"tokenAddress"`
+			Price        string `json:"price"`
+		}
+		if err := decoder.Decode(&record); err != nil {
+			return nil, false, err
+		}
+		if record.BlockNumber == blockNumber && common.HexToAddress(record.TokenAddress) == tokenAddress {
+			price := bigNumber.NewInt(0)
+			price.SetString(record.Price, 10)
+			return price, true, nil
+		}
+	}
+	return nil, false, nil
+}
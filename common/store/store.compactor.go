@@ -0,0 +1,144 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/yearn/ydaemon/common/logs"
+)
+
+/**************************************************************************************************
+** Compactable is implemented by backends that can physically rewrite a table to drop the records a
+** RetentionConfig decided not to keep. Badger implements it; MySQL does not, and is expected to
+** manage its own retention through ordinary SQL housekeeping instead of this path.
+**
+** archive is called for every record keep rejects, before the implementation may delete it from hot
+** storage: a record only belongs in the returned dropped slice (and only becomes eligible for
+** deletion) once archive has returned nil for it. This guarantees a record is never removed from
+** hot storage without first having been durably written to the cold tier (or, if no ColdTier is
+** configured for the table, without RunCompaction's no-op archive func having been given the
+** chance to skip it cleanly either way).
+**************************************************************************************************/
+type Compactable interface {
+	CompactBlockTime(chainID uint64, keep func(entry BlockTimeEntry) bool, archive func(entry BlockTimeEntry) error) (dropped []BlockTimeEntry, err error)
+	CompactHistoricalPrice(chainID uint64, keep func(entry HistoricalPriceEntry) bool, archive func(entry HistoricalPriceEntry) error) (dropped []HistoricalPriceEntry, err error)
+}
+
+/**************************************************************************************************
+** RunCompaction walks chainID's block_time and historical_price tables against whatever
+** RetentionConfig was set for them via SetRetentionPolicy, physically dropping anything the policy
+** rejects and, if a ColdTier is configured for that table, archiving it there first, grouped by
+** week, instead of discarding it outright.
+**************************************************************************************************/
+func RunCompaction(chainID uint64, headBlock uint64) error {
+	backend, err := backendFor(chainID)
+	if err != nil {
+		return err
+	}
+	compactable, ok := backend.(Compactable)
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+
+	if config := retentionConfigFor(`block_time`); config.Policy != RetentionKeepAll {
+		sampler := newRetentionSampler()
+		dropped, err := compactable.CompactBlockTime(chainID, func(entry BlockTimeEntry) bool {
+			return config.shouldKeep(entry.BlockNumber, time.Unix(int64(entry.BlockTime), 0), headBlock, now, sampler)
+		}, func(entry BlockTimeEntry) error {
+			return archiveBlockTime(chainID, config.ColdTier, entry)
+		})
+		if err != nil {
+			return fmt.Errorf(`store: compact block_time for chain %d: %w`, chainID, err)
+		}
+		logs.Info(`Compacted ` + strconv.Itoa(len(dropped)) + ` block_time entries for chain ` + strconv.FormatUint(chainID, 10))
+	}
+
+	if config := retentionConfigFor(`historical_price`); config.Policy != RetentionKeepAll {
+		sampler := newRetentionSampler()
+		dropped, err := compactable.CompactHistoricalPrice(chainID, func(entry HistoricalPriceEntry) bool {
+			// Historical prices carry no timestamp of their own; they borrow the block's recorded
+			// time. A block time we can't find yet is treated as "keep" so a lookup gap never
+			// silently deletes price history it has no way to date.
+			blockTime, found, _ := backend.GetBlockTime(chainID, entry.BlockNumber)
+			if !found {
+				return true
+			}
+			return config.shouldKeep(entry.BlockNumber, time.Unix(int64(blockTime), 0), headBlock, now, sampler)
+		}, func(entry HistoricalPriceEntry) error {
+			return archiveHistoricalPrice(chainID, backend, config.ColdTier, entry)
+		})
+		if err != nil {
+			return fmt.Errorf(`store: compact historical_price for chain %d: %w`, chainID, err)
+		}
+		logs.Info(`Compacted ` + strconv.Itoa(len(dropped)) + ` historical_price entries for chain ` + strconv.FormatUint(chainID, 10))
+	}
+
+	return nil
+}
+
+// RunCompactionLoop runs RunCompaction on a timer until ctx is cancelled, logging and continuing
+// past a failed pass rather than stopping the background compactor over one bad run. headBlock is
+// read fresh on every tick so the loop keeps pace with chain sync without needing its own head
+// tracking.
+func RunCompactionLoop(ctx context.Context, chainID uint64, interval time.Duration, headBlock func() uint64) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := RunCompaction(chainID, headBlock()); err != nil {
+				logs.Error(`Compaction failed for chain ` + strconv.FormatUint(chainID, 10) + `: ` + err.Error())
+			}
+		}
+	}
+}
+
+func archiveBlockTime(chainID uint64, coldTier ColdTier, entry BlockTimeEntry) error {
+	if coldTier == nil {
+		return nil
+	}
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	week := WeekOf(int64(entry.BlockTime))
+	if err := coldTier.Write(chainID, `block_time`, week, append(payload, '\n')); err != nil {
+		return err
+	}
+	coldBloomFor(chainID, `block_time`).Add(strconv.FormatUint(entry.BlockNumber, 10))
+	return nil
+}
+
+func archiveHistoricalPrice(chainID uint64, backend Backend, coldTier ColdTier, entry HistoricalPriceEntry) error {
+	if coldTier == nil {
+		return nil
+	}
+	payload, err := json.Marshal(struct {
+		BlockNumber  uint64 `json:"blockNumber"`
+		TokenAddress string `json:"tokenAddress"`
+		Price        string `json:"price"`
+	}{entry.BlockNumber, entry.TokenAddress.Hex(), entry.Price.String()})
+	if err != nil {
+		return err
+	}
+	// Historical prices have no timestamp of their own to bucket by week; they share the week their
+	// block's own time falls into, matching how GetHistoricalPriceWithColdFallback resolves the week
+	// on the read side. Only fall back to "now" when that block was never recorded.
+	week := WeekOf(time.Now().Unix())
+	if blockTime, found, err := backend.GetBlockTime(chainID, entry.BlockNumber); err == nil && found {
+		week = WeekOf(int64(blockTime))
+	}
+	key := strconv.FormatUint(entry.BlockNumber, 10) + `_` + entry.TokenAddress.Hex()
+	if err := coldTier.Write(chainID, `historical_price`, week, append(payload, '\n')); err != nil {
+		return err
+	}
+	coldBloomFor(chainID, `historical_price`).Add(key)
+	return nil
+}
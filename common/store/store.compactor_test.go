@@ -0,0 +1,133 @@
+package store
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/yearn/ydaemon/common/bigNumber"
+	"github.com/yearn/ydaemon/internal/models"
+)
+
+// failingColdTier fails every Write once writes reaches failAfter, so tests can simulate a cold
+// tier that starts erroring partway through a compaction pass (disk full, S3 error, ...).
+type failingColdTier struct {
+	failAfter int
+	writes    int
+	written   []string
+}
+
+func (c *failingColdTier) Write(chainID uint64, table string, week int64, data []byte) error {
+	c.writes++
+	if c.writes > c.failAfter {
+		return errors.New(`cold tier write failed`)
+	}
+	c.written = append(c.written, string(data))
+	return nil
+}
+
+func (c *failingColdTier) Read(chainID uint64, table string, week int64) ([]byte, error) {
+	return nil, errors.New(`not implemented`)
+}
+
+// fakeCompactableBackend is a minimal Backend+Compactable that mirrors the badger backend's
+// archive-before-delete contract: an entry is only removed from hotBlockTime once archive(entry)
+// has returned nil for it, so a test can assert nothing is lost from hot storage when archiving
+// fails partway through a pass.
+type fakeCompactableBackend struct {
+	hotBlockTime []BlockTimeEntry
+}
+
+func (b *fakeCompactableBackend) CompactBlockTime(chainID uint64, keep func(entry BlockTimeEntry) bool, archive func(entry BlockTimeEntry) error) ([]BlockTimeEntry, error) {
+	var dropped []BlockTimeEntry
+	var kept []BlockTimeEntry
+	for _, entry := range b.hotBlockTime {
+		if keep(entry) {
+			kept = append(kept, entry)
+			continue
+		}
+		if err := archive(entry); err != nil {
+			// Stop at the first archive failure, leaving every entry from here on (including this
+			// one) untouched in hot storage. badger.go is stricter: it deletes nothing at all from a
+			// pass that fails, including the entries archived before the failure.
+			b.hotBlockTime = append(kept, b.hotBlockTime[len(kept)+len(dropped):]...)
+			return dropped, err
+		}
+		dropped = append(dropped, entry)
+	}
+	b.hotBlockTime = kept
+	return dropped, nil
+}
+
+func (b *fakeCompactableBackend) CompactHistoricalPrice(chainID uint64, keep func(entry HistoricalPriceEntry) bool, archive func(entry HistoricalPriceEntry) error) ([]HistoricalPriceEntry, error) {
+	return nil, nil
+}
+
+func (b *fakeCompactableBackend) PutBlockTimeBatch(chainID uint64, entries []BlockTimeEntry) error {
+	return nil
+}
+func (b *fakeCompactableBackend) PutHistoricalPriceBatch(chainID uint64, entries []HistoricalPriceEntry) error {
+	return nil
+}
+func (b *fakeCompactableBackend) PutVaultBatch(chainID uint64, entries []*models.TVault) error {
+	return nil
+}
+func (b *fakeCompactableBackend) PutNewVaultFromRegistryBatch(chainID uint64, entries []models.TVaultsFromRegistry) error {
+	return nil
+}
+func (b *fakeCompactableBackend) GetBlockTime(chainID uint64, blockNumber uint64) (uint64, bool, error) {
+	return 0, false, nil
+}
+func (b *fakeCompactableBackend) GetHistoricalPrice(chainID uint64, blockNumber uint64, tokenAddress common.Address) (*bigNumber.Int, bool, error) {
+	return nil, false, nil
+}
+func (b *fakeCompactableBackend) IterateBlockTime(chainID uint64, fn func(blockNumber uint64, blockTime uint64) error) error {
+	return nil
+}
+func (b *fakeCompactableBackend) IterateHistoricalPrice(chainID uint64, fn func(key string, price *bigNumber.Int) error) error {
+	return nil
+}
+func (b *fakeCompactableBackend) IterateVault(chainID uint64, fn func(key string, vault *models.TVault) error) error {
+	return nil
+}
+func (b *fakeCompactableBackend) IterateNewVaultFromRegistry(chainID uint64, fn func(key string, vault models.TVaultsFromRegistry) error) error {
+	return nil
+}
+func (b *fakeCompactableBackend) Close() error { return nil }
+
+func TestRunCompactionDoesNotDropUnarchivedEntries(t *testing.T) {
+	chainID := uint64(900003)
+	backend := &fakeCompactableBackend{
+		hotBlockTime: []BlockTimeEntry{
+			{BlockNumber: 1, BlockTime: 1},
+			{BlockNumber: 2, BlockTime: 2},
+			{BlockNumber: 3, BlockTime: 3},
+		},
+	}
+	coldTier := &failingColdTier{failAfter: 1}
+
+	_backendsMu.Lock()
+	_backends[chainID] = backend
+	_backendsMu.Unlock()
+	defer func() {
+		_backendsMu.Lock()
+		delete(_backends, chainID)
+		_backendsMu.Unlock()
+	}()
+
+	SetRetentionPolicy(`block_time`, RetentionConfig{Policy: RetentionKeepLastNBlocks, KeepLastNBlocks: 0, ColdTier: coldTier})
+	defer SetRetentionPolicy(`block_time`, RetentionConfig{})
+
+	err := RunCompaction(chainID, 100)
+	if err == nil {
+		t.Fatal(`expected RunCompaction to surface the cold tier write failure`)
+	}
+
+	if len(backend.hotBlockTime) != 2 {
+		t.Fatalf(`expected the 2 entries that were never successfully archived to remain in hot storage, got %d: %+v`,
+			len(backend.hotBlockTime), backend.hotBlockTime)
+	}
+	if len(coldTier.written) != 1 {
+		t.Fatalf(`expected exactly 1 entry to have been archived before the failure, got %d`, len(coldTier.written))
+	}
+}
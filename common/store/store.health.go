@@ -0,0 +1,59 @@
+package store
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+/**************************************************************************************************
+** HealthThresholds configures when the monitor in this file trips Halt automatically for a chain.
+** A zero field disables that particular check, so a caller only has to set the limits it cares
+** about.
+**************************************************************************************************/
+type HealthThresholds struct {
+	// MaxQueueDepth halts a chain once any of its batched write queues (store.writer.go) holds this
+	// many entries, which is what the MySQL backend falling behind indexing looks like in practice.
+	MaxQueueDepth int
+	// MaxFlushDuration halts a chain once a batch flush takes longer than this. Badger has no public
+	// API to ask "are you compaction-stalled right now", so an unusually slow flush is used as the
+	// observable proxy for one.
+	MaxFlushDuration time.Duration
+}
+
+var (
+	_healthThresholds   HealthThresholds
+	_healthThresholdsMu sync.RWMutex
+)
+
+// SetHealthThresholds wires the limits the health monitor evaluates on every queue push and batch
+// flush. Call once at startup from config.
+func SetHealthThresholds(thresholds HealthThresholds) {
+	_healthThresholdsMu.Lock()
+	defer _healthThresholdsMu.Unlock()
+	_healthThresholds = thresholds
+}
+
+func healthThresholds() HealthThresholds {
+	_healthThresholdsMu.RLock()
+	defer _healthThresholdsMu.RUnlock()
+	return _healthThresholds
+}
+
+// reportQueueDepth is called from batchWriter.Enqueue on every push.
+func reportQueueDepth(chainID uint64, depth int) {
+	thresholds := healthThresholds()
+	if thresholds.MaxQueueDepth <= 0 || depth < thresholds.MaxQueueDepth || IsHalted(chainID) {
+		return
+	}
+	Halt(chainID, `write queue depth `+strconv.Itoa(depth)+` reached threshold `+strconv.Itoa(thresholds.MaxQueueDepth))
+}
+
+// reportFlushDuration is called from batchWriter.run after every flush.
+func reportFlushDuration(chainID uint64, duration time.Duration) {
+	thresholds := healthThresholds()
+	if thresholds.MaxFlushDuration <= 0 || duration < thresholds.MaxFlushDuration || IsHalted(chainID) {
+		return
+	}
+	Halt(chainID, `flush took `+duration.String()+`, past threshold `+thresholds.MaxFlushDuration.String()+` (possible Badger compaction stall)`)
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"fmt"
+	"sync"
+)
+
+var (
+	_backends   = map[uint64]Backend{}
+	_backendsMu sync.RWMutex
+)
+
+/**************************************************************************************************
+** backendName maps the configured _dbType to the string key a Backend is registered under, so the
+** existing DBBadger/DBMysql config values keep working unchanged. Only those two values map to a
+** name; any other _dbType yields `` and backendFor fails with "no backend registered", so a new
+** backend is only selectable once it has its own case here.
+**************************************************************************************************/
+func backendName() string {
+	switch _dbType {
+	case DBBadger:
+		return `badger`
+	case DBMysql:
+		return `mysql`
+	default:
+		return ``
+	}
+}
+
+/**************************************************************************************************
+** backendFor lazily instantiates and caches the Backend configured for chainID, so every Store*
+** helper shares the same backend instance instead of re-opening connections per call.
+**************************************************************************************************/
+func backendFor(chainID uint64) (Backend, error) {
+	_backendsMu.RLock()
+	backend, ok := _backends[chainID]
+	_backendsMu.RUnlock()
+	if ok {
+		return backend, nil
+	}
+
+	name := backendName()
+	factory, ok := _backendFactories[name]
+	if !ok {
+		return nil, fmt.Errorf(`store: no backend registered for %q`, name)
+	}
+
+	backend, err := factory(chainID)
+	if err != nil {
+		return nil, err
+	}
+
+	_backendsMu.Lock()
+	_backends[chainID] = backend
+	_backendsMu.Unlock()
+	return backend, nil
+}
@@ -0,0 +1,103 @@
+package store
+
+import (
+	"sync"
+	"time"
+)
+
+/**************************************************************************************************
+** RetentionPolicy controls how long the compactor in store.compactor.go keeps a record in hot
+** storage before it is eligible for pruning (and, if a ColdTier is configured, offloading there).
+**************************************************************************************************/
+type RetentionPolicy int
+
+const (
+	// RetentionKeepAll never prunes anything. This is the default and matches the behavior every
+	// table had before retention policies existed.
+	RetentionKeepAll RetentionPolicy = iota
+	// RetentionKeepLastNBlocks drops any record older than RetentionConfig.KeepLastNBlocks blocks
+	// relative to the chain's current head.
+	RetentionKeepLastNBlocks
+	// RetentionTimeDecayed keeps every record from the last 7 days, thins to 1-per-hour for records
+	// between 7 and 30 days old, and to 1-per-day beyond that.
+	RetentionTimeDecayed
+)
+
+/**************************************************************************************************
+** RetentionConfig is set per table via SetRetentionPolicy, typically once at startup from config.
+**************************************************************************************************/
+type RetentionConfig struct {
+	Policy          RetentionPolicy
+	KeepLastNBlocks uint64
+
+	// ColdTier receives anything the compactor prunes from hot storage instead of discarding it. A
+	// nil ColdTier means pruned records are gone for good once compacted.
+	ColdTier ColdTier
+}
+
+var _retentionConfig = map[string]RetentionConfig{} // table name -> config
+
+// SetRetentionPolicy wires a per-table retention policy into the store package. Tables with no
+// policy set default to RetentionKeepAll, i.e. today's behavior.
+func SetRetentionPolicy(table string, config RetentionConfig) {
+	_retentionConfig[table] = config
+}
+
+func retentionConfigFor(table string) RetentionConfig {
+	return _retentionConfig[table]
+}
+
+/**************************************************************************************************
+** retentionSampler carries the state RetentionTimeDecayed needs to actually thin records down to
+** one representative per bucket, instead of testing each record in isolation against "does this
+** timestamp land exactly on a zero-minute/midnight instant" (which almost nothing ever satisfies,
+** since the (block, token) points this runs over are sparse and irregular to begin with). Create a
+** fresh sampler per compaction pass with newRetentionSampler: it is not safe to reuse across passes,
+** since the first record seen in each bucket is the one the pass keeps.
+**************************************************************************************************/
+type retentionSampler struct {
+	mu       sync.Mutex
+	seenHour map[int64]bool
+	seenDay  map[int64]bool
+}
+
+func newRetentionSampler() *retentionSampler {
+	return &retentionSampler{seenHour: map[int64]bool{}, seenDay: map[int64]bool{}}
+}
+
+func (s *retentionSampler) keepOncePerBucket(seen map[int64]bool, bucket int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if seen[bucket] {
+		return false
+	}
+	seen[bucket] = true
+	return true
+}
+
+// shouldKeep reports whether a record at blockNumber/recordTime survives this RetentionConfig given
+// the chain's current head. sampler is only consulted (and must only be non-nil) for
+// RetentionTimeDecayed; pass a freshly created one per compaction pass.
+func (c RetentionConfig) shouldKeep(blockNumber uint64, recordTime time.Time, headBlock uint64, now time.Time, sampler *retentionSampler) bool {
+	switch c.Policy {
+	case RetentionKeepLastNBlocks:
+		if headBlock < c.KeepLastNBlocks {
+			return true
+		}
+		return blockNumber >= headBlock-c.KeepLastNBlocks
+	case RetentionTimeDecayed:
+		age := now.Sub(recordTime)
+		switch {
+		case age <= 7*24*time.Hour:
+			return true
+		case age <= 30*24*time.Hour:
+			return sampler.keepOncePerBucket(sampler.seenHour, recordTime.Truncate(time.Hour).Unix())
+		default:
+			return sampler.keepOncePerBucket(sampler.seenDay, recordTime.Truncate(24*time.Hour).Unix())
+		}
+	case RetentionKeepAll:
+		fallthrough
+	default:
+		return true
+	}
+}
@@ -0,0 +1,59 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetentionConfigShouldKeep(t *testing.T) {
+	now := time.Date(2026, 7, 26, 12, 0, 0, 0, time.UTC)
+
+	t.Run(`keep all never drops`, func(t *testing.T) {
+		config := RetentionConfig{Policy: RetentionKeepAll}
+		if !config.shouldKeep(1, now, 100, now, nil) {
+			t.Fatal(`RetentionKeepAll should always keep`)
+		}
+	})
+
+	t.Run(`keep last N blocks drops anything older than the window`, func(t *testing.T) {
+		config := RetentionConfig{Policy: RetentionKeepLastNBlocks, KeepLastNBlocks: 10}
+		if !config.shouldKeep(95, now, 100, now, nil) {
+			t.Fatal(`block within the window should be kept`)
+		}
+		if config.shouldKeep(80, now, 100, now, nil) {
+			t.Fatal(`block outside the window should be dropped`)
+		}
+	})
+
+	t.Run(`time decayed downsamples to one record per hour bucket`, func(t *testing.T) {
+		config := RetentionConfig{Policy: RetentionTimeDecayed}
+		sampler := newRetentionSampler()
+		base := now.Add(-10 * 24 * time.Hour)
+
+		if !config.shouldKeep(1, base, 0, now, sampler) {
+			t.Fatal(`first record in a fresh hour bucket should be kept`)
+		}
+		if config.shouldKeep(2, base.Add(10*time.Minute), 0, now, sampler) {
+			t.Fatal(`second record in the same hour bucket should be dropped`)
+		}
+		if !config.shouldKeep(3, base.Add(90*time.Minute), 0, now, sampler) {
+			t.Fatal(`record in a new hour bucket should be kept`)
+		}
+	})
+
+	t.Run(`time decayed downsamples to one record per day bucket beyond 30 days`, func(t *testing.T) {
+		config := RetentionConfig{Policy: RetentionTimeDecayed}
+		sampler := newRetentionSampler()
+		base := now.Add(-40 * 24 * time.Hour)
+
+		if !config.shouldKeep(1, base, 0, now, sampler) {
+			t.Fatal(`first record in a fresh day bucket should be kept`)
+		}
+		if config.shouldKeep(2, base.Add(2*time.Hour), 0, now, sampler) {
+			t.Fatal(`second record in the same day bucket should be dropped`)
+		}
+		if !config.shouldKeep(3, base.Add(36*time.Hour), 0, now, sampler) {
+			t.Fatal(`record in a new day bucket should be kept`)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package store
+
+import (
+	"bytes"
+	"strconv"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/yearn/ydaemon/common/bigNumber"
+	"github.com/yearn/ydaemon/internal/models"
+)
+
+// fakeSnapshotBackend is a minimal Backend used on both ends of an Export/ImportSnapshot round
+// trip: as the export source it iterates the entries seeded into it, and as the import
+// destination it just records whatever batches ImportSnapshot hands to its Put*Batch methods.
+type fakeSnapshotBackend struct {
+	blockTimes        []BlockTimeEntry
+	historicalPrices  []HistoricalPriceEntry
+	importedBlockTime []BlockTimeEntry
+	importedPrices    []HistoricalPriceEntry
+	putBatchCalls     int
+}
+
+func (b *fakeSnapshotBackend) PutBlockTimeBatch(chainID uint64, entries []BlockTimeEntry) error {
+	b.putBatchCalls++
+	b.importedBlockTime = append(b.importedBlockTime, entries...)
+	return nil
+}
+func (b *fakeSnapshotBackend) PutHistoricalPriceBatch(chainID uint64, entries []HistoricalPriceEntry) error {
+	b.putBatchCalls++
+	b.importedPrices = append(b.importedPrices, entries...)
+	return nil
+}
+func (b *fakeSnapshotBackend) PutVaultBatch(chainID uint64, entries []*models.TVault) error {
+	b.putBatchCalls++
+	return nil
+}
+func (b *fakeSnapshotBackend) PutNewVaultFromRegistryBatch(chainID uint64, entries []models.TVaultsFromRegistry) error {
+	b.putBatchCalls++
+	return nil
+}
+func (b *fakeSnapshotBackend) GetBlockTime(chainID uint64, blockNumber uint64) (uint64, bool, error) {
+	return 0, false, nil
+}
+func (b *fakeSnapshotBackend) GetHistoricalPrice(chainID uint64, blockNumber uint64, tokenAddress common.Address) (*bigNumber.Int, bool, error) {
+	return nil, false, nil
+}
+func (b *fakeSnapshotBackend) IterateBlockTime(chainID uint64, fn func(blockNumber uint64, blockTime uint64) error) error {
+	for _, entry := range b.blockTimes {
+		if err := fn(entry.BlockNumber, entry.BlockTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func (b *fakeSnapshotBackend) IterateHistoricalPrice(chainID uint64, fn func(key string, price *bigNumber.Int) error) error {
+	for _, entry := range b.historicalPrices {
+		key := historicalPriceKey(entry.BlockNumber, entry.TokenAddress)
+		if err := fn(key, entry.Price); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+func (b *fakeSnapshotBackend) IterateVault(chainID uint64, fn func(key string, vault *models.TVault) error) error {
+	return nil
+}
+func (b *fakeSnapshotBackend) IterateNewVaultFromRegistry(chainID uint64, fn func(key string, vault models.TVaultsFromRegistry) error) error {
+	return nil
+}
+func (b *fakeSnapshotBackend) Close() error { return nil }
+
+func setBackend(chainID uint64, backend Backend) {
+	_backendsMu.Lock()
+	_backends[chainID] = backend
+	_backendsMu.Unlock()
+}
+
+func clearBackend(chainID uint64) {
+	_backendsMu.Lock()
+	delete(_backends, chainID)
+	_backendsMu.Unlock()
+}
+
+func TestSnapshotExportImportRoundTrip(t *testing.T) {
+	chainID := uint64(900004)
+	price := bigNumber.NewInt(0)
+	price.SetString(`42`, 10)
+	source := &fakeSnapshotBackend{
+		blockTimes: []BlockTimeEntry{
+			{BlockNumber: 1, BlockTime: 100},
+			{BlockNumber: 2, BlockTime: 200},
+		},
+		historicalPrices: []HistoricalPriceEntry{
+			{BlockNumber: 1, TokenAddress: common.HexToAddress(`0x1111111111111111111111111111111111111111`), Price: price},
+		},
+	}
+	setBackend(chainID, source)
+
+	var buf bytes.Buffer
+	if err := ExportSnapshot(chainID, &buf); err != nil {
+		t.Fatalf(`ExportSnapshot: %v`, err)
+	}
+
+	destination := &fakeSnapshotBackend{}
+	setBackend(chainID, destination)
+	defer clearBackend(chainID)
+
+	if err := ImportSnapshot(chainID, bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf(`ImportSnapshot: %v`, err)
+	}
+
+	if len(destination.importedBlockTime) != len(source.blockTimes) {
+		t.Fatalf(`expected %d imported block times, got %d`, len(source.blockTimes), len(destination.importedBlockTime))
+	}
+	if len(destination.importedPrices) != len(source.historicalPrices) {
+		t.Fatalf(`expected %d imported historical prices, got %d`, len(source.historicalPrices), len(destination.importedPrices))
+	}
+}
+
+func TestSnapshotImportRejectsChecksumMismatchBeforeWritingAnything(t *testing.T) {
+	chainID := uint64(900005)
+	source := &fakeSnapshotBackend{
+		blockTimes: []BlockTimeEntry{{BlockNumber: 1, BlockTime: 100}},
+	}
+	setBackend(chainID, source)
+
+	var buf bytes.Buffer
+	if err := ExportSnapshot(chainID, &buf); err != nil {
+		t.Fatalf(`ExportSnapshot: %v`, err)
+	}
+
+	corrupted := buf.Bytes()
+	// Change the block number digit inside the block_time record's payload (past the 5-byte frame
+	// header). The payload still decodes, so only the manifest's checksum can catch the change.
+	corrupted[5+len(`{"BlockNumber":`)] = '2'
+
+	destination := &fakeSnapshotBackend{}
+	setBackend(chainID, destination)
+	defer clearBackend(chainID)
+
+	err := ImportSnapshot(chainID, bytes.NewReader(corrupted))
+	if err == nil {
+		t.Fatal(`expected ImportSnapshot to reject a corrupted snapshot`)
+	}
+	if destination.putBatchCalls != 0 {
+		t.Fatalf(`expected no Put*Batch calls against the backend before the checksum is verified, got %d`, destination.putBatchCalls)
+	}
+}
+
+func historicalPriceKey(blockNumber uint64, tokenAddress common.Address) string {
+	return strconv.FormatUint(blockNumber, 10) + `_` + tokenAddress.Hex()
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+/**************************************************************************************************
+** tableStats is the per-table payload returned by StatsHandler: how many keys are cached in the
+** sync.Map layer for that table, the bloom filter's count of keys the compactor has offloaded to
+** cold storage, and the hit rate of MightBeCold lookups against that bloom. Hot and cold counts can
+** both be non-zero for the same table: a hit in the cold bloom only means a key *might* have been
+** archived, not that it left hot storage. ColdHitRate is -1 when no lookups have happened yet, so a
+** genuine 0% hit rate isn't confused with "no data".
+**************************************************************************************************/
+type tableStats struct {
+	Table           string  `json:"table"`
+	HotEntries      int     `json:"hotEntries"`
+	ColdEntries     int64   `json:"coldEntries"`
+	ColdHitRate     float64 `json:"coldHitRate"`
+	RetentionPolicy string  `json:"retentionPolicy"`
+}
+
+func newTableStats(table string, hotEntries int) tableStats {
+	bloomStats := coldBloomStatsForTable(table)
+	hitRate := -1.0
+	if total := bloomStats.Hits + bloomStats.Misses; total > 0 {
+		hitRate = float64(bloomStats.Hits) / float64(total)
+	}
+	return tableStats{
+		Table:           table,
+		HotEntries:      hotEntries,
+		ColdEntries:     bloomStats.ColdEntries,
+		ColdHitRate:     hitRate,
+		RetentionPolicy: retentionConfigFor(table).Policy.String(),
+	}
+}
+
+// StatsHandler serves a JSON snapshot of what the store package currently knows about its own
+// tables: how many entries each sync.Map cache holds, how many keys the compactor has archived to
+// cold storage, how often cold lookups pay off, and which retention policy, if any, governs each
+// table. It is deliberately framework-agnostic (plain net/http) so whatever router the daemon wires
+// up can mount it at /debug/store/stats without pulling this package into a web framework.
+func StatsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		stats := []tableStats{
+			newTableStats(`block_time`, syncMapLen(_blockTimeSyncMap)),
+			newTableStats(`historical_price`, syncMapLen(_historicalPriceSyncMap)),
+			newTableStats(`vault`, syncMapLen(_vaultsSyncMap)),
+			newTableStats(`new_vaults_from_registry`, syncMapLen(_newVaultsFromRegistrySyncMap)),
+		}
+
+		w.Header().Set(`Content-Type`, `application/json`)
+		if err := json.NewEncoder(w).Encode(stats); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+func syncMapLen(byChain map[uint64]*sync.Map) int {
+	total := 0
+	for _, syncMap := range byChain {
+		syncMap.Range(func(_, _ interface{}) bool {
+			total++
+			return true
+		})
+	}
+	return total
+}
+
+func (p RetentionPolicy) String() string {
+	switch p {
+	case RetentionKeepLastNBlocks:
+		return `keep_last_n_blocks`
+	case RetentionTimeDecayed:
+		return `time_decayed`
+	case RetentionKeepAll:
+		fallthrough
+	default:
+		return `keep_all`
+	}
+}
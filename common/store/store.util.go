@@ -0,0 +1,10 @@
+package store
+
+/**************************************************************************************************
+** GetUUID exposes this package's deterministic UUID derivation to the backend implementations in
+** backend/badger and backend/mysql, which need to build the exact same UUIDs the MySQL schema has
+** always used but live outside this package.
+**************************************************************************************************/
+func GetUUID(seed string) string {
+	return getUUID(seed)
+}
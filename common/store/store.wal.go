@@ -0,0 +1,218 @@
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/yearn/ydaemon/common/bigNumber"
+	"github.com/yearn/ydaemon/common/logs"
+	"github.com/yearn/ydaemon/internal/models"
+)
+
+// _walMaxBytesPerChain bounds each chain's on-disk WAL. Past this, spillToWAL refuses new records
+// rather than letting a chain that is halted for a long time grow the WAL without limit; the
+// dropped write is logged so it is not lost silently.
+const _walMaxBytesPerChain = 256 << 20
+
+var _walBaseDir = filepath.Join(`data`, `wal`)
+
+type walRecord struct {
+	Table   string          `json:"table"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+/**************************************************************************************************
+** walFile is one chain's append-only spill log: length-prefixed (4-byte big-endian uint32) JSON
+** frames, each a walRecord naming the table the entry belongs to. It exists so Store* writes made
+** while Halt is in effect are not lost, and so Resume can replay them through the batched writer
+** from store.writer.go in order.
+**************************************************************************************************/
+type walFile struct {
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+var _walFiles sync.Map // uint64 -> *walFile
+
+func walPath(chainID uint64) string {
+	return filepath.Join(_walBaseDir, strconv.FormatUint(chainID, 10), `wal.log`)
+}
+
+func walFileFor(chainID uint64) (*walFile, error) {
+	if f, ok := _walFiles.Load(chainID); ok {
+		return f.(*walFile), nil
+	}
+
+	path := walPath(chainID)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	wf := &walFile{file: file, size: info.Size()}
+	actual, loaded := _walFiles.LoadOrStore(chainID, wf)
+	if loaded {
+		file.Close()
+		return actual.(*walFile), nil
+	}
+	return wf, nil
+}
+
+// spillToWAL appends one (table, payload) record to chainID's WAL. payload must already be in a
+// form safe to pass to json.Marshal directly (see the callers in store.setter.go).
+func spillToWAL(chainID uint64, table string, payload interface{}) error {
+	rawPayload, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	frame, err := json.Marshal(walRecord{Table: table, Payload: rawPayload})
+	if err != nil {
+		return err
+	}
+
+	wf, err := walFileFor(chainID)
+	if err != nil {
+		return err
+	}
+
+	wf.mu.Lock()
+	defer wf.mu.Unlock()
+	if wf.size+int64(len(frame))+4 > _walMaxBytesPerChain {
+		return fmt.Errorf(`store: WAL for chain %d is full, dropping %s record`, chainID, table)
+	}
+	header := make([]byte, 4)
+	binary.BigEndian.PutUint32(header, uint32(len(frame)))
+	if _, err := wf.file.Write(header); err != nil {
+		return err
+	}
+	if _, err := wf.file.Write(frame); err != nil {
+		return err
+	}
+	wf.size += int64(len(header)) + int64(len(frame))
+	return nil
+}
+
+/**************************************************************************************************
+** replayWAL drains chainID's WAL, enqueuing every record onto the matching batched writer from
+** store.writer.go, then truncates the file. It is called by Resume and should not be invoked
+** directly.
+**************************************************************************************************/
+func replayWAL(chainID uint64) error {
+	wf, err := walFileFor(chainID)
+	if err != nil {
+		return err
+	}
+
+	wf.mu.Lock()
+	defer wf.mu.Unlock()
+
+	if _, err := wf.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	records, err := decodeWALFrames(wf.file)
+	if err != nil {
+		return fmt.Errorf(`store: read WAL for chain %d: %w`, chainID, err)
+	}
+	for _, record := range records {
+		if err := replayWALRecord(chainID, record); err != nil {
+			return err
+		}
+	}
+
+	if err := wf.file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := wf.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	wf.size = 0
+
+	if len(records) > 0 {
+		logs.Info(`store: replayed ` + strconv.Itoa(len(records)) + ` WAL records for chain ` + strconv.FormatUint(chainID, 10))
+	}
+	return nil
+}
+
+// decodeWALFrames reads every length-prefixed walRecord frame from r until EOF. It is split out of
+// replayWAL so the on-disk framing can be exercised directly in tests without needing a registered
+// Backend behind the batched writer each record replays into.
+func decodeWALFrames(r io.Reader) ([]walRecord, error) {
+	var records []walRecord
+	for {
+		header := make([]byte, 4)
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		frame := make([]byte, binary.BigEndian.Uint32(header))
+		if _, err := io.ReadFull(r, frame); err != nil {
+			return nil, err
+		}
+		var record walRecord
+		if err := json.Unmarshal(frame, &record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func replayWALRecord(chainID uint64, record walRecord) error {
+	switch record.Table {
+	case `block_time`:
+		var entry BlockTimeEntry
+		if err := json.Unmarshal(record.Payload, &entry); err != nil {
+			return err
+		}
+		blockTimeWriter(chainID).Enqueue(entry)
+
+	case `historical_price`:
+		var priced snapshotHistoricalPriceRecord
+		if err := json.Unmarshal(record.Payload, &priced); err != nil {
+			return err
+		}
+		price := bigNumber.NewInt(0)
+		price.SetString(priced.Price, 10)
+		historicalPriceWriter(chainID).Enqueue(HistoricalPriceEntry{
+			BlockNumber:  priced.BlockNumber,
+			TokenAddress: common.HexToAddress(priced.TokenAddress),
+			Price:        price,
+		})
+
+	case `vault`:
+		vault := &models.TVault{}
+		if err := json.Unmarshal(record.Payload, vault); err != nil {
+			return err
+		}
+		vaultWriter(chainID).Enqueue(vault)
+
+	case `new_vaults_from_registry`:
+		var vault models.TVaultsFromRegistry
+		if err := json.Unmarshal(record.Payload, &vault); err != nil {
+			return err
+		}
+		newVaultsFromRegistryWriter(chainID).Enqueue(vault)
+
+	default:
+		return fmt.Errorf(`store: unknown WAL table %q for chain %d`, record.Table, chainID)
+	}
+	return nil
+}
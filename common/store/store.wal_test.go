@@ -0,0 +1,69 @@
+package store
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestSpillToWALRoundTrip(t *testing.T) {
+	chainID := uint64(900001)
+	_walBaseDir = t.TempDir()
+	_walFiles.Delete(chainID)
+
+	if err := spillToWAL(chainID, `block_time`, BlockTimeEntry{BlockNumber: 1, BlockTime: 2}); err != nil {
+		t.Fatalf(`spillToWAL: %v`, err)
+	}
+	if err := spillToWAL(chainID, `block_time`, BlockTimeEntry{BlockNumber: 3, BlockTime: 4}); err != nil {
+		t.Fatalf(`spillToWAL: %v`, err)
+	}
+
+	raw, err := os.ReadFile(walPath(chainID))
+	if err != nil {
+		t.Fatalf(`read WAL file: %v`, err)
+	}
+	records, err := decodeWALFrames(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf(`decodeWALFrames: %v`, err)
+	}
+	if len(records) != 2 {
+		t.Fatalf(`expected 2 records, got %d`, len(records))
+	}
+	if records[0].Table != `block_time` || records[1].Table != `block_time` {
+		t.Fatalf(`unexpected record tables: %+v`, records)
+	}
+}
+
+func TestHaltBlocksUntilResumeDrainsWAL(t *testing.T) {
+	chainID := uint64(900002)
+	_walBaseDir = t.TempDir()
+	_walFiles.Delete(chainID)
+	_haltStates.Delete(chainID)
+
+	Halt(chainID, `test halt`)
+	if !IsHalted(chainID) {
+		t.Fatal(`expected chain to be halted`)
+	}
+	if HaltReason(chainID) != `test halt` {
+		t.Fatalf(`unexpected halt reason: %q`, HaltReason(chainID))
+	}
+
+	if err := spillToWAL(chainID, `block_time`, BlockTimeEntry{BlockNumber: 1, BlockTime: 2}); err != nil {
+		t.Fatalf(`spillToWAL: %v`, err)
+	}
+
+	if err := Resume(chainID); err != nil {
+		t.Fatalf(`Resume: %v`, err)
+	}
+	if IsHalted(chainID) {
+		t.Fatal(`expected chain to no longer be halted after Resume`)
+	}
+
+	info, err := os.Stat(walPath(chainID))
+	if err != nil {
+		t.Fatalf(`stat WAL: %v`, err)
+	}
+	if info.Size() != 0 {
+		t.Fatalf(`expected WAL to be drained by Resume, got size %d`, info.Size())
+	}
+}
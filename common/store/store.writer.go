@@ -0,0 +1,230 @@
+package store
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/yearn/ydaemon/common/logs"
+	"github.com/yearn/ydaemon/internal/models"
+)
+
+const (
+	_writeBatchSize     = 500
+	_writeFlushInterval = 2 * time.Second
+	_writeQueueCapacity = 10_000
+)
+
+var (
+	metricWriteQueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: `ydaemon`,
+		Subsystem: `store`,
+		Name:      `write_queue_depth`,
+		Help:      `Number of entries currently buffered for a (chain, table) write queue.`,
+	}, []string{`chain_id`, `table`})
+
+	metricWriteBatchSize = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: `ydaemon`,
+		Subsystem: `store`,
+		Name:      `write_batch_size`,
+		Help:      `Number of entries flushed to the backend in a single batch.`,
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+	}, []string{`chain_id`, `table`})
+
+	metricWriteFlushSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: `ydaemon`,
+		Subsystem: `store`,
+		Name:      `write_flush_seconds`,
+		Help:      `Time spent flushing one batch to the backend.`,
+	}, []string{`chain_id`, `table`})
+)
+
+func init() {
+	prometheus.MustRegister(metricWriteQueueDepth, metricWriteBatchSize, metricWriteFlushSeconds)
+}
+
+/**************************************************************************************************
+** batchWriter coalesces writes for a single (chainID, table) pair into fixed-size batches flushed
+** by one dedicated goroutine, so syncing tens of thousands of rows does a handful of batched
+** backend writes instead of launching one goroutine and one round-trip per row. A batch flushes
+** once it reaches _writeBatchSize entries or _writeFlushInterval has elapsed, whichever is first.
+**************************************************************************************************/
+type batchWriter[T any] struct {
+	chainID uint64
+	table   string
+	flush   func(chainID uint64, entries []T) error
+
+	queue chan T
+	done  chan struct{}
+	wg    sync.WaitGroup
+}
+
+func newBatchWriter[T any](chainID uint64, table string, flush func(chainID uint64, entries []T) error) *batchWriter[T] {
+	w := &batchWriter[T]{
+		chainID: chainID,
+		table:   table,
+		flush:   flush,
+		queue:   make(chan T, _writeQueueCapacity),
+		done:    make(chan struct{}),
+	}
+	w.wg.Add(1)
+	go w.run()
+	return w
+}
+
+// Enqueue pushes an entry onto the write queue. It blocks once the queue is full, which is the
+// backpressure: a backend that falls behind slows down the indexer goroutines feeding it instead of
+// piling up an unbounded number of in-flight goroutines like the old per-call `go func(){}` did.
+func (w *batchWriter[T]) Enqueue(entry T) {
+	w.queue <- entry
+	depth := len(w.queue)
+	metricWriteQueueDepth.WithLabelValues(w.chainIDLabel(), w.table).Set(float64(depth))
+	reportQueueDepth(w.chainID, depth)
+}
+
+func (w *batchWriter[T]) chainIDLabel() string {
+	return strconv.FormatUint(w.chainID, 10)
+}
+
+func (w *batchWriter[T]) run() {
+	defer w.wg.Done()
+	ticker := time.NewTicker(_writeFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]T, 0, _writeBatchSize)
+	flushNow := func() {
+		if len(batch) == 0 {
+			return
+		}
+		start := time.Now()
+		if err := w.flush(w.chainID, batch); err != nil {
+			logs.Error(err)
+		}
+		elapsed := time.Since(start)
+		metricWriteBatchSize.WithLabelValues(w.chainIDLabel(), w.table).Observe(float64(len(batch)))
+		metricWriteFlushSeconds.WithLabelValues(w.chainIDLabel(), w.table).Observe(elapsed.Seconds())
+		reportFlushDuration(w.chainID, elapsed)
+		batch = batch[:0]
+		metricWriteQueueDepth.WithLabelValues(w.chainIDLabel(), w.table).Set(float64(len(w.queue)))
+	}
+
+	for {
+		select {
+		case entry, ok := <-w.queue:
+			if !ok {
+				flushNow()
+				return
+			}
+			batch = append(batch, entry)
+			if len(batch) >= _writeBatchSize {
+				flushNow()
+			}
+		case <-ticker.C:
+			flushNow()
+		case <-w.done:
+			// Drain whatever is already queued before exiting so Shutdown never drops a write.
+			for {
+				select {
+				case entry := <-w.queue:
+					batch = append(batch, entry)
+				default:
+					flushNow()
+					return
+				}
+			}
+		}
+	}
+}
+
+// Shutdown stops accepting new work implicitly (callers must stop enqueuing) and blocks until the
+// current batch, plus anything already queued, has been flushed to the backend.
+func (w *batchWriter[T]) Shutdown() {
+	close(w.done)
+	w.wg.Wait()
+}
+
+var (
+	_blockTimeWriters            sync.Map // uint64 -> *batchWriter[BlockTimeEntry]
+	_historicalPriceWriters      sync.Map // uint64 -> *batchWriter[HistoricalPriceEntry]
+	_vaultWriters                sync.Map // uint64 -> *batchWriter[*models.TVault]
+	_newVaultsFromRegistryWriter sync.Map // uint64 -> *batchWriter[models.TVaultsFromRegistry]
+)
+
+func blockTimeWriter(chainID uint64) *batchWriter[BlockTimeEntry] {
+	if w, ok := _blockTimeWriters.Load(chainID); ok {
+		return w.(*batchWriter[BlockTimeEntry])
+	}
+	w := newBatchWriter(chainID, `block_time`, func(chainID uint64, entries []BlockTimeEntry) error {
+		backend, err := backendFor(chainID)
+		if err != nil {
+			return err
+		}
+		return backend.PutBlockTimeBatch(chainID, entries)
+	})
+	actual, _ := _blockTimeWriters.LoadOrStore(chainID, w)
+	return actual.(*batchWriter[BlockTimeEntry])
+}
+
+func historicalPriceWriter(chainID uint64) *batchWriter[HistoricalPriceEntry] {
+	if w, ok := _historicalPriceWriters.Load(chainID); ok {
+		return w.(*batchWriter[HistoricalPriceEntry])
+	}
+	w := newBatchWriter(chainID, `historical_price`, func(chainID uint64, entries []HistoricalPriceEntry) error {
+		backend, err := backendFor(chainID)
+		if err != nil {
+			return err
+		}
+		return backend.PutHistoricalPriceBatch(chainID, entries)
+	})
+	actual, _ := _historicalPriceWriters.LoadOrStore(chainID, w)
+	return actual.(*batchWriter[HistoricalPriceEntry])
+}
+
+func vaultWriter(chainID uint64) *batchWriter[*models.TVault] {
+	if w, ok := _vaultWriters.Load(chainID); ok {
+		return w.(*batchWriter[*models.TVault])
+	}
+	w := newBatchWriter(chainID, `vault`, func(chainID uint64, entries []*models.TVault) error {
+		backend, err := backendFor(chainID)
+		if err != nil {
+			return err
+		}
+		return backend.PutVaultBatch(chainID, entries)
+	})
+	actual, _ := _vaultWriters.LoadOrStore(chainID, w)
+	return actual.(*batchWriter[*models.TVault])
+}
+
+func newVaultsFromRegistryWriter(chainID uint64) *batchWriter[models.TVaultsFromRegistry] {
+	if w, ok := _newVaultsFromRegistryWriter.Load(chainID); ok {
+		return w.(*batchWriter[models.TVaultsFromRegistry])
+	}
+	w := newBatchWriter(chainID, `new_vaults_from_registry`, func(chainID uint64, entries []models.TVaultsFromRegistry) error {
+		backend, err := backendFor(chainID)
+		if err != nil {
+			return err
+		}
+		return backend.PutNewVaultFromRegistryBatch(chainID, entries)
+	})
+	actual, _ := _newVaultsFromRegistryWriter.LoadOrStore(chainID, w)
+	return actual.(*batchWriter[models.TVaultsFromRegistry])
+}
+
+/**************************************************************************************************
+** Shutdown drains every per-(chain, table) write queue, blocking until all pending writes have been
+** flushed to their backend. Call this before the process exits so a sync interrupted mid-flight
+** never silently loses the last partial batch.
+**************************************************************************************************/
+func Shutdown() {
+	drain := func(m *sync.Map) {
+		m.Range(func(_, value interface{}) bool {
+			value.(interface{ Shutdown() }).Shutdown()
+			return true
+		})
+	}
+	drain(&_blockTimeWriters)
+	drain(&_historicalPriceWriters)
+	drain(&_vaultWriters)
+	drain(&_newVaultsFromRegistryWriter)
+}
@@ -0,0 +1,57 @@
+package store
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestBatchWriterFlushesAtBatchSize(t *testing.T) {
+	var mu sync.Mutex
+	var flushes [][]int
+
+	w := newBatchWriter(1, `test_table`, func(chainID uint64, entries []int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		flushes = append(flushes, append([]int{}, entries...))
+		return nil
+	})
+
+	for i := 0; i < _writeBatchSize; i++ {
+		w.Enqueue(i)
+	}
+	w.Shutdown()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(flushes) != 1 {
+		t.Fatalf(`expected exactly 1 flush once the batch hit _writeBatchSize, got %d`, len(flushes))
+	}
+	if len(flushes[0]) != _writeBatchSize {
+		t.Fatalf(`expected the flush to carry all %d entries, got %d`, _writeBatchSize, len(flushes[0]))
+	}
+}
+
+func TestBatchWriterShutdownDrainsPendingEntries(t *testing.T) {
+	var mu sync.Mutex
+	var flushed []int
+
+	w := newBatchWriter(2, `test_table`, func(chainID uint64, entries []int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		flushed = append(flushed, entries...)
+		return nil
+	})
+
+	w.Enqueue(1)
+	w.Enqueue(2)
+	w.Enqueue(3)
+	// None of these reach _writeBatchSize or the flush ticker, so nothing should have been flushed
+	// yet; Shutdown must drain them anyway rather than dropping them on the floor.
+	w.Shutdown()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(flushed) != 3 {
+		t.Fatalf(`expected Shutdown to drain all 3 queued entries, got %d: %v`, len(flushed), flushed)
+	}
+}